@@ -0,0 +1,97 @@
+package config
+
+import (
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DriverBuilder composes an ordered list of FeatureSteps into the final driver SparkPod. It is meant
+// to replace the previous flow of the controller stashing annotations on the SparkApplication for the
+// admission webhook to mutate the Pod from later, but that cutover is not done: this package does not
+// contain the controller or admission webhook, so nothing outside this package's own tests calls
+// Build yet. Wiring a caller to it, and to ValidateImagePullSecrets, is a pending follow-up.
+type DriverBuilder struct {
+	steps []FeatureStep
+}
+
+// NewDriverBuilder returns a DriverBuilder running the default driver FeatureSteps, in the order the
+// repo has historically applied them: ConfigMaps, Secrets and Volumes before Kerberos and local
+// dirs, with dependency resolution last since it rewrites spark.jars/spark.files.
+func NewDriverBuilder(app *v1alpha1.SparkApplication, namespace string, depManager *RemoteDependencyManager) *DriverBuilder {
+	return &DriverBuilder{
+		steps: []FeatureStep{
+			NewLegacyAnnotationStep(app, SparkRoleDriver),
+			NewBasicStep(app, SparkRoleDriver),
+			NewSparkConfigMapStep(app),
+			NewHadoopConfigMapStep(app),
+			NewMountSecretsStep(app, SparkRoleDriver),
+			NewMountVolumesStep(app, SparkRoleDriver),
+			NewImagePullSecretsStep(app),
+			NewKerberosStep(app),
+			NewLocalDirsStep(app),
+			NewDependencyResolutionStep(app, namespace, depManager),
+		},
+	}
+}
+
+// Build renders the final driver Pod by running every step, in order, over a bare Pod and its
+// SparkDriverContainerName container. It returns the resulting Pod, the SparkConf overrides every
+// step contributed, and any additional resources (ConfigMaps, Secrets) that must be created before
+// the Pod. It returns the first error any step reports instead of rendering a partially-configured
+// Pod.
+func (b *DriverBuilder) Build() (*apiv1.Pod, map[string]string, []runtime.Object, error) {
+	return build(b.steps, SparkDriverContainerName)
+}
+
+// ExecutorBuilder composes an ordered list of FeatureSteps into the final executor SparkPod.
+type ExecutorBuilder struct {
+	steps []FeatureStep
+}
+
+// NewExecutorBuilder returns an ExecutorBuilder running the default executor FeatureSteps. Unlike
+// the driver, executors never run HadoopConfigMapStep or KerberosStep: Hadoop configuration and
+// Kerberos credentials are resolved once by the driver and serialized with the tasks it schedules.
+func NewExecutorBuilder(app *v1alpha1.SparkApplication, namespace string, depManager *RemoteDependencyManager) *ExecutorBuilder {
+	return &ExecutorBuilder{
+		steps: []FeatureStep{
+			NewLegacyAnnotationStep(app, SparkRoleExecutor),
+			NewBasicStep(app, SparkRoleExecutor),
+			NewSparkConfigMapStep(app),
+			NewMountSecretsStep(app, SparkRoleExecutor),
+			NewMountVolumesStep(app, SparkRoleExecutor),
+			NewImagePullSecretsStep(app),
+			NewLocalDirsStep(app),
+			NewDependencyResolutionStep(app, namespace, depManager),
+		},
+	}
+}
+
+// Build renders the final executor Pod; see DriverBuilder.Build.
+func (b *ExecutorBuilder) Build() (*apiv1.Pod, map[string]string, []runtime.Object, error) {
+	return build(b.steps, SparkExecutorContainerName)
+}
+
+func build(steps []FeatureStep, containerName string) (*apiv1.Pod, map[string]string, []runtime.Object, error) {
+	pod := SparkPod{
+		Pod:       &apiv1.Pod{},
+		Container: &apiv1.Container{Name: containerName},
+	}
+	sparkConf := make(map[string]string)
+	var resources []runtime.Object
+
+	for _, step := range steps {
+		var err error
+		pod, err = step.ConfigurePod(pod)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for k, v := range step.SparkConfOverrides() {
+			sparkConf[k] = v
+		}
+		resources = append(resources, step.AdditionalResources()...)
+	}
+	pod.Pod.Spec.Containers = append(pod.Pod.Spec.Containers, *pod.Container)
+	return pod.Pod, sparkConf, resources, nil
+}
@@ -100,8 +100,9 @@ func CreateSparkConfigMap(sparkConfDir string, namespace string, app *v1alpha1.S
 	}
 
 	// Add an annotation to the driver and executor Pods so the initializer gets informed.
-	AddConfigMapAnnotation(app, SparkDriverAnnotationsKey, SparkConfigMapAnnotation, name)
-	AddConfigMapAnnotation(app, SparkExecutorAnnotationsKey, SparkConfigMapAnnotation, name)
+	kubernetesConf := NewKubernetesConf(app)
+	kubernetesConf.DriverConf().SetAnnotation(SparkConfigMapAnnotation, name)
+	kubernetesConf.ExecutorConf().SetAnnotation(SparkConfigMapAnnotation, name)
 	// Update the Spec to include the name of the newly created ConfigMap.
 	app.Spec.SparkConfigMap = new(string)
 	*app.Spec.SparkConfigMap = name
@@ -117,8 +118,9 @@ func CreateHadoopConfigMap(hadoopConfDir string, namespace string, app *v1alpha1
 	}
 
 	// Add an annotation to the driver and executor Pods so the initializer gets informed.
-	AddConfigMapAnnotation(app, SparkDriverAnnotationsKey, HadoopConfigMapAnnotation, name)
-	AddConfigMapAnnotation(app, SparkExecutorAnnotationsKey, HadoopConfigMapAnnotation, name)
+	kubernetesConf := NewKubernetesConf(app)
+	kubernetesConf.DriverConf().SetAnnotation(HadoopConfigMapAnnotation, name)
+	kubernetesConf.ExecutorConf().SetAnnotation(HadoopConfigMapAnnotation, name)
 	// Update the Spec to include the name of the newly created ConfigMap.
 	app.Spec.HadoopConfigMap = new(string)
 	*app.Spec.HadoopConfigMap = name
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+	"github.com/liyinan926/spark-operator/pkg/util"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DependencyConfigMapNamePrefix is the name prefix of the ConfigMap holding the list of remote
+	// dependencies to download, created from app.Spec.Deps.
+	DependencyConfigMapNamePrefix = "spark-deps-config-map"
+	// DependencyConfigMapVolumeName is the name of the ConfigMap volume mounted into the init-container.
+	DependencyConfigMapVolumeName = "spark-deps-config-map-volume"
+	// DependencyConfigMapMountPath is where the dependency ConfigMap is mounted in the init-container.
+	DependencyConfigMapMountPath = "/etc/spark-deps"
+	// DependencyConfigFileName is the ConfigMap data key the download list is stored under, formatted
+	// as one "uri=localPath" pair per line.
+	DependencyConfigFileName = "remote-deps.properties"
+
+	// DefaultJarsDownloadDir is where Deps.Jars land when JarsDownloadDir is unset.
+	DefaultJarsDownloadDir = "/var/spark-data/spark-jars"
+	// DefaultFilesDownloadDir is where Deps.Files and Deps.PyFiles land when FilesDownloadDir is unset.
+	DefaultFilesDownloadDir = "/var/spark-data/spark-files"
+
+	// SparkSubmitPyFilesKey is the Spark configuration key for the comma-separated list of Python
+	// dependencies (.py/.zip/.egg) placed on the PYTHONPATH of the driver and executors. Unlike jars,
+	// these are not JVM classpath entries, so they never belong on spark.jars.
+	SparkSubmitPyFilesKey = "spark.submit.pyFiles"
+
+	// SparkDependencyInitContainerName is the name of the generated init-container.
+	SparkDependencyInitContainerName = "spark-init"
+	// DefaultDependencyDownloaderImage is the init-container image used when the operator is not
+	// configured with a different one.
+	DefaultDependencyDownloaderImage = "gcr.io/spark-operator/spark-dep-downloader:latest"
+)
+
+// RemoteDependencyManager resolves a SparkApplication's remote application dependencies
+// (http(s)://, s3a://, gs://, hdfs://) declared in Spec.Deps into an init-container that downloads
+// them into emptyDir volumes shared with the main Spark container before Spark starts, and rewrites
+// spark.jars/spark.files to the resulting local paths.
+type RemoteDependencyManager struct {
+	downloaderImage string
+}
+
+// NewRemoteDependencyManager creates a RemoteDependencyManager whose init-container uses
+// downloaderImage, falling back to DefaultDependencyDownloaderImage if downloaderImage is empty.
+func NewRemoteDependencyManager(downloaderImage string) *RemoteDependencyManager {
+	if downloaderImage == "" {
+		downloaderImage = DefaultDependencyDownloaderImage
+	}
+	return &RemoteDependencyManager{downloaderImage: downloaderImage}
+}
+
+// BuildDependenciesConfigMap builds the ConfigMap listing every remote dependency declared in
+// app.Spec.Deps and the local path it should be downloaded to, named deterministically from its
+// contents the same way buildConfigMapFromConfigDir names the Spark/Hadoop ConfigMaps. It returns a
+// nil ConfigMap if app.Spec.Deps declares no remote dependencies.
+func (m *RemoteDependencyManager) BuildDependenciesConfigMap(app *v1alpha1.SparkApplication, namespace string) *apiv1.ConfigMap {
+	deps := app.Spec.Deps
+	if deps == nil || (len(deps.Jars) == 0 && len(deps.Files) == 0 && len(deps.PyFiles) == 0) {
+		return nil
+	}
+
+	jarsDir := jarsDownloadDir(deps)
+	filesDir := filesDownloadDir(deps)
+
+	var lines []string
+	for _, uri := range deps.Jars {
+		lines = append(lines, fmt.Sprintf("%s=%s", uri, path.Join(jarsDir, path.Base(uri))))
+	}
+	for _, uri := range deps.PyFiles {
+		lines = append(lines, fmt.Sprintf("%s=%s", uri, path.Join(filesDir, path.Base(uri))))
+	}
+	for _, uri := range deps.Files {
+		lines = append(lines, fmt.Sprintf("%s=%s", uri, path.Join(filesDir, path.Base(uri))))
+	}
+	contents := strings.Join(lines, "\n") + "\n"
+
+	hasher := util.NewHash32()
+	hasher.Write([]byte(contents))
+	hasher.Write([]byte(namespace))
+	hasher.Write([]byte(app.UID))
+
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", DependencyConfigMapNamePrefix, hasher.Sum32()),
+			Namespace: namespace,
+		},
+		Data: map[string]string{DependencyConfigFileName: contents},
+	}
+}
+
+// ConfigureRemoteDependencies mounts the dependency ConfigMap produced by BuildDependenciesConfigMap
+// into a new init-container, adds emptyDir volumes for the jars/files download directories shared
+// between the init-container and mainContainer, mounts any credential Secrets listed in
+// app.Spec.Deps.Secrets into the init-container only, and returns spark.jars/spark.files/
+// spark.submit.pyFiles rewritten to the local download paths, merged with whatever app.Spec.SparkConf
+// already set. It returns a nil map if configMapName is empty. The caller, not this function, is
+// responsible for surfacing the returned overrides through FeatureStep.SparkConfOverrides.
+func (m *RemoteDependencyManager) ConfigureRemoteDependencies(app *v1alpha1.SparkApplication, configMapName string, pod *apiv1.Pod, mainContainer *apiv1.Container) map[string]string {
+	deps := app.Spec.Deps
+	if deps == nil || configMapName == "" {
+		return nil
+	}
+
+	jarsDir := jarsDownloadDir(deps)
+	filesDir := filesDownloadDir(deps)
+
+	configMapVolumeName := addConfigMapVolumeToPod(configMapName, DependencyConfigMapVolumeName, pod)
+	initContainer := apiv1.Container{
+		Name:  SparkDependencyInitContainerName,
+		Image: m.downloaderImage,
+		Args:  []string{path.Join(DependencyConfigMapMountPath, DependencyConfigFileName)},
+		VolumeMounts: []apiv1.VolumeMount{
+			{Name: configMapVolumeName, ReadOnly: true, MountPath: DependencyConfigMapMountPath},
+		},
+	}
+
+	for _, dir := range uniqueNonEmpty(jarsDir, filesDir) {
+		volumeName := downloadDirVolumeName(dir)
+		pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+			Name:         volumeName,
+			VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}},
+		})
+		volumeMount := apiv1.VolumeMount{Name: volumeName, MountPath: dir}
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, volumeMount)
+		mainContainer.VolumeMounts = append(mainContainer.VolumeMounts, volumeMount)
+	}
+
+	for _, secretName := range deps.Secrets {
+		mountSecretVolume(secretName, depsSecretVolumeName(secretName), path.Join("/mnt/secrets", secretName), true, pod, &initContainer)
+	}
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
+
+	sparkConf := make(map[string]string)
+	if len(deps.Jars) > 0 {
+		sparkConf["spark.jars"] = mergeCommaList(app.Spec.SparkConf["spark.jars"], localPaths(deps.Jars, jarsDir))
+	}
+	if len(deps.Files) > 0 {
+		sparkConf["spark.files"] = mergeCommaList(app.Spec.SparkConf["spark.files"], localPaths(deps.Files, filesDir))
+	}
+	if len(deps.PyFiles) > 0 {
+		sparkConf[SparkSubmitPyFilesKey] = mergeCommaList(app.Spec.SparkConf[SparkSubmitPyFilesKey], localPaths(deps.PyFiles, filesDir))
+	}
+	return sparkConf
+}
+
+func jarsDownloadDir(deps *v1alpha1.Dependencies) string {
+	if deps.JarsDownloadDir != "" {
+		return deps.JarsDownloadDir
+	}
+	return DefaultJarsDownloadDir
+}
+
+func filesDownloadDir(deps *v1alpha1.Dependencies) string {
+	if deps.FilesDownloadDir != "" {
+		return deps.FilesDownloadDir
+	}
+	return DefaultFilesDownloadDir
+}
+
+// mergeCommaList appends newValues to the comma-separated list already set in existing (e.g. local
+// jars, or local:// URIs the user set directly), rather than overwriting it, so resolving remote
+// dependencies never silently drops what was already there.
+func mergeCommaList(existing string, newValues []string) string {
+	var values []string
+	if existing != "" {
+		values = strings.Split(existing, ",")
+	}
+	values = append(values, newValues...)
+	return strings.Join(values, ",")
+}
+
+func localPaths(uris []string, dir string) []string {
+	paths := make([]string, len(uris))
+	for i, uri := range uris {
+		paths[i] = path.Join(dir, path.Base(uri))
+	}
+	return paths
+}
+
+func downloadDirVolumeName(dir string) string {
+	hasher := util.NewHash32()
+	hasher.Write([]byte(dir))
+	return fmt.Sprintf("download-dir-%d", hasher.Sum32())
+}
+
+func depsSecretVolumeName(secretName string) string {
+	return fmt.Sprintf("deps-secret-%s", secretName)
+}
+
+func uniqueNonEmpty(values ...string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SparkPod pairs a driver or executor Pod template with its main Spark container. Most FeatureStep
+// implementations need to add a Volume to the Pod and a matching VolumeMount or EnvVar to the
+// container in lockstep, so steps are given both rather than the Pod alone.
+type SparkPod struct {
+	Pod       *apiv1.Pod
+	Container *apiv1.Container
+}
+
+// FeatureStep is a single, independently testable unit of Pod configuration, e.g. mounting the Spark
+// ConfigMap, injecting Kerberos credentials, or resolving remote dependencies. DriverBuilder and
+// ExecutorBuilder compose FeatureSteps into the final Pod at render time. This is meant to replace the
+// previous flow of stashing comma-separated annotations on the SparkApplication for the admission
+// webhook to parse and apply later, but the controller/webhook call site that would invoke the
+// builders instead lives outside this package and has not been cut over yet.
+type FeatureStep interface {
+	// ConfigurePod mutates and returns the given SparkPod. It returns an error if the
+	// SparkApplication's configuration for this step is invalid; the builder aborts rather than
+	// rendering a Pod that silently dropped part of the step's configuration.
+	ConfigurePod(pod SparkPod) (SparkPod, error)
+	// AdditionalResources returns any Kubernetes objects (ConfigMaps, Secrets) this step needs created
+	// alongside the Pod, or nil if it needs none.
+	AdditionalResources() []runtime.Object
+	// SparkConfOverrides returns the Spark configuration properties this step contributes, e.g.
+	// spark.kerberos.principal, or nil if it contributes none.
+	SparkConfOverrides() map[string]string
+}
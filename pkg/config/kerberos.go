@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KerberosKeytabVolumeName is the name of the Secret volume that holds the keytab used to log into
+	// the Kerberos KDC.
+	KerberosKeytabVolumeName = "kerberos-keytab-volume"
+	// KerberosKeytabMountPath is where the keytab Secret is mounted in the driver container.
+	KerberosKeytabMountPath = "/mnt/secrets/kerberos"
+	// DefaultKerberosKeytabSecretKey is the Secret data key the keytab is read from when
+	// KerberosConf.KeytabSecretKey is unset.
+	DefaultKerberosKeytabSecretKey = "keytab"
+	// KerberosTokenVolumeName is the name of the Secret volume that holds pre-obtained Hadoop
+	// delegation tokens.
+	KerberosTokenVolumeName = "hadoop-tokens-volume"
+	// KerberosTokenMountPath is where the delegation token Secret is mounted in the driver container.
+	KerberosTokenMountPath = "/mnt/secrets/hadoop-tokens"
+	// DefaultKerberosTokenSecretKey is the Secret data key the delegation token is read from when
+	// KerberosConf.ExistingSecretKey is unset.
+	DefaultKerberosTokenSecretKey = "hadoop.tokens"
+	// KerberosKrb5ConfVolumeName is the name of the ConfigMap volume that holds krb5.conf.
+	KerberosKrb5ConfVolumeName = "krb5-conf-volume"
+	// KerberosKrb5ConfMountPath is where krb5.conf is mounted in the driver container.
+	KerberosKrb5ConfMountPath = "/etc/krb5.conf"
+	// KerberosKrb5ConfFileName is the key the krb5.conf ConfigMap data is expected under.
+	KerberosKrb5ConfFileName = "krb5.conf"
+
+	// SparkKerberosPrincipalKey is the Spark configuration key for the principal the driver logs in as.
+	SparkKerberosPrincipalKey = "spark.kerberos.principal"
+	// SparkKerberosKeytabKey is the Spark configuration key for the path to the mounted keytab.
+	SparkKerberosKeytabKey = "spark.kerberos.keytab"
+	// HadoopTokenFileLocationEnvVar is the environment variable the driver's
+	// HadoopDelegationTokenManager reads to locate pre-obtained delegation tokens instead of logging in
+	// with a keytab.
+	HadoopTokenFileLocationEnvVar = "HADOOP_TOKEN_FILE_LOCATION"
+)
+
+// ConfigureKerberosForDriver mounts the keytab Secret or existing delegation-token Secret, and the
+// krb5.conf ConfigMap, declared in app.Spec.HadoopConf.Kerberos into the driver Pod and container, and
+// returns the Spark configuration the driver's HadoopDelegationTokenManager needs to pick them up. It
+// returns a nil map if no Kerberos configuration was declared. The caller, not this function, is
+// responsible for surfacing the returned overrides through FeatureStep.SparkConfOverrides.
+//
+// Kerberos configuration is intentionally never applied to executor Pods: the driver resolves
+// delegation tokens once and serializes them with the tasks it schedules, so there is no
+// ConfigureKerberosForExecutor counterpart.
+func ConfigureKerberosForDriver(app *v1alpha1.SparkApplication, driverPod *apiv1.Pod, driverContainer *apiv1.Container) (map[string]string, error) {
+	hadoopConf := app.Spec.HadoopConf
+	if hadoopConf == nil || hadoopConf.Kerberos == nil {
+		return nil, nil
+	}
+	kerberos := hadoopConf.Kerberos
+
+	sparkConf := make(map[string]string)
+
+	switch {
+	case kerberos.KeytabSecret != "":
+		if kerberos.Principal == "" {
+			return nil, fmt.Errorf("spec.hadoopConf.kerberos.principal must be set when keytab is used")
+		}
+		mountSecretVolume(kerberos.KeytabSecret, KerberosKeytabVolumeName, KerberosKeytabMountPath, true, driverPod, driverContainer)
+
+		keytabKey := kerberos.KeytabSecretKey
+		if keytabKey == "" {
+			keytabKey = DefaultKerberosKeytabSecretKey
+		}
+		sparkConf[SparkKerberosPrincipalKey] = kerberos.Principal
+		sparkConf[SparkKerberosKeytabKey] = filepath.Join(KerberosKeytabMountPath, keytabKey)
+	case kerberos.ExistingSecret != "":
+		mountSecretVolume(kerberos.ExistingSecret, KerberosTokenVolumeName, KerberosTokenMountPath, true, driverPod, driverContainer)
+
+		tokenKey := kerberos.ExistingSecretKey
+		if tokenKey == "" {
+			tokenKey = DefaultKerberosTokenSecretKey
+		}
+		// HADOOP_TOKEN_FILE_LOCATION must point at the token file itself, not the Secret's mount
+		// directory: UserGroupInformation reads it as a single credentials file.
+		driverContainer.Env = append(driverContainer.Env, apiv1.EnvVar{
+			Name:  HadoopTokenFileLocationEnvVar,
+			Value: filepath.Join(KerberosTokenMountPath, tokenKey),
+		})
+	}
+
+	if kerberos.Krb5ConfigMap != "" {
+		addConfigMapVolumeToPod(kerberos.Krb5ConfigMap, KerberosKrb5ConfVolumeName, driverPod)
+		driverContainer.VolumeMounts = append(driverContainer.VolumeMounts, apiv1.VolumeMount{
+			Name:      KerberosKrb5ConfVolumeName,
+			ReadOnly:  true,
+			MountPath: KerberosKrb5ConfMountPath,
+			SubPath:   KerberosKrb5ConfFileName,
+		})
+	}
+
+	return sparkConf, nil
+}
+
+// mountSecretVolume adds a Secret volume to pod and a matching VolumeMount to container.
+func mountSecretVolume(secretName string, volumeName string, mountPath string, readOnly bool, pod *apiv1.Pod, container *apiv1.Container) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+		Name: volumeName,
+		VolumeSource: apiv1.VolumeSource{
+			Secret: &apiv1.SecretVolumeSource{SecretName: secretName},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, apiv1.VolumeMount{
+		Name:      volumeName,
+		ReadOnly:  readOnly,
+		MountPath: mountPath,
+	})
+}
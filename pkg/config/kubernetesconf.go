@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+)
+
+const (
+	driverLabelPrefix          = "spark.kubernetes.driver.label."
+	executorLabelPrefix        = "spark.kubernetes.executor.label."
+	driverNodeSelectorPrefix   = "spark.kubernetes.driver.node.selector."
+	executorNodeSelectorPrefix = "spark.kubernetes.executor.node.selector."
+
+	// sparkRoleLabel is the label key Spark itself uses to tell driver and executor Pods apart; like
+	// SparkAppIDLabel, it can never be overridden by a user-supplied label.
+	sparkRoleLabel = "spark-role"
+)
+
+// reservedLabels can never be set through RoleConf.SetLabel: they identify the SparkApplication and
+// its Pod's role to the controller and to Spark itself.
+var reservedLabels = map[string]bool{
+	SparkAppIDLabel: true,
+	sparkRoleLabel:  true,
+}
+
+// KubernetesConf is a typed façade over the spark.kubernetes.* configuration namespaces the
+// controller and FeatureSteps previously mutated as a raw map[string]string on
+// SparkApplicationSpec.SparkConf. It owns parsing and serializing the prefixed key namespaces
+// (spark.kubernetes.driver.label.*, spark.kubernetes.driverEnv.*, spark.executorEnv.*, etc.) behind a
+// role-scoped RoleConf, so tests can build a KubernetesConf with defaults and override only what they
+// care about instead of hand-assembling SparkConf keys.
+type KubernetesConf struct {
+	app *v1alpha1.SparkApplication
+}
+
+// NewKubernetesConf returns a KubernetesConf façade over app.Spec.SparkConf, initializing the map if
+// it is nil.
+func NewKubernetesConf(app *v1alpha1.SparkApplication) *KubernetesConf {
+	if app.Spec.SparkConf == nil {
+		app.Spec.SparkConf = make(map[string]string)
+	}
+	return &KubernetesConf{app: app}
+}
+
+// DriverConf returns the RoleConf scoped to the driver's spark.kubernetes.driver.* keys.
+func (c *KubernetesConf) DriverConf() *RoleConf {
+	return &RoleConf{
+		conf:                 c.app.Spec.SparkConf,
+		labelPrefix:          driverLabelPrefix,
+		nodeSelectorPrefix:   driverNodeSelectorPrefix,
+		annotationsKey:       SparkDriverAnnotationsKey,
+		envVarPrefix:         DriverEnvVarConfigKeyPrefix,
+		secretsAnnotation:    DriverSecretsAnnotation,
+		configMapsAnnotation: DriverConfigMapsAnnotation,
+	}
+}
+
+// ExecutorConf returns the RoleConf scoped to the executor's spark.kubernetes.executor.*/
+// spark.executorEnv.* keys.
+func (c *KubernetesConf) ExecutorConf() *RoleConf {
+	return &RoleConf{
+		conf:                 c.app.Spec.SparkConf,
+		labelPrefix:          executorLabelPrefix,
+		nodeSelectorPrefix:   executorNodeSelectorPrefix,
+		annotationsKey:       SparkExecutorAnnotationsKey,
+		envVarPrefix:         ExecutorEnvVarConfigKeyPrefix,
+		secretsAnnotation:    ExecutorSecretsAnnotation,
+		configMapsAnnotation: ExecutorConfigMapsAnnotation,
+	}
+}
+
+// Snapshot returns the flattened spark.kubernetes.*/spark.*Env.* configuration map ready for
+// spark-submit, i.e. app.Spec.SparkConf itself. Mutate it only through DriverConf/ExecutorConf so
+// reserved labels keep being validated.
+func (c *KubernetesConf) Snapshot() map[string]string {
+	return c.app.Spec.SparkConf
+}
+
+// RoleConf is a typed, role-scoped view over one SparkApplication's driver or executor
+// spark.kubernetes.* configuration keys.
+type RoleConf struct {
+	conf                 map[string]string
+	labelPrefix          string
+	nodeSelectorPrefix   string
+	annotationsKey       string
+	envVarPrefix         string
+	secretsAnnotation    string
+	configMapsAnnotation string
+}
+
+// SetLabel sets a user label on the role's Pod. It returns an error without setting anything if key
+// is reserved for use by the controller or Spark itself.
+func (r *RoleConf) SetLabel(key string, value string) error {
+	if reservedLabels[key] {
+		return fmt.Errorf("label %q is reserved and cannot be overridden", key)
+	}
+	r.conf[r.labelPrefix+key] = value
+	return nil
+}
+
+// Labels returns the user labels set on the role's Pod, keyed without the role's label prefix.
+func (r *RoleConf) Labels() map[string]string {
+	return r.prefixed(r.labelPrefix)
+}
+
+// SetNodeSelector sets a node selector on the role's Pod.
+func (r *RoleConf) SetNodeSelector(key string, value string) {
+	r.conf[r.nodeSelectorPrefix+key] = value
+}
+
+// NodeSelectors returns the node selectors set on the role's Pod.
+func (r *RoleConf) NodeSelectors() map[string]string {
+	return r.prefixed(r.nodeSelectorPrefix)
+}
+
+// SetAnnotation sets an annotation on the role's Pod, replacing any value already set under the same
+// key instead of appending a duplicate entry to the comma-joined annotations conf key.
+func (r *RoleConf) SetAnnotation(key string, value string) {
+	setAnnotationValue(r.conf, r.annotationsKey, key, value)
+}
+
+// Annotations returns the annotations set on the role's Pod, keyed by annotation name.
+func (r *RoleConf) Annotations() map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(r.conf[r.annotationsKey], ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			result[kv[0]] = kv[1]
+		}
+	}
+	return result
+}
+
+// SetEnvVar sets an environment variable on the role's main container.
+func (r *RoleConf) SetEnvVar(name string, value string) {
+	r.conf[r.envVarPrefix+name] = value
+}
+
+// EnvVars returns the environment variables set on the role's main container, keyed without the
+// role's env var prefix.
+func (r *RoleConf) EnvVars() map[string]string {
+	return r.prefixed(r.envVarPrefix)
+}
+
+// AddSecret declares that the Secret named name should be mounted at mountPath into the role's Pod.
+func (r *RoleConf) AddSecret(name string, mountPath string) {
+	appendAnnotationValue(r.conf, r.annotationsKey, r.secretsAnnotation, fmt.Sprintf("%s:%s", name, mountPath))
+}
+
+// Secrets returns the Secret entries declared for the role: "name:mountPath" as AddSecret writes them,
+// or "name:mountPath:type" where the entry carries a Secret type.
+func (r *RoleConf) Secrets() []string {
+	return annotationValues(r.conf[r.annotationsKey], r.secretsAnnotation)
+}
+
+// ConfigMaps returns the "name:mountPath" pairs declared for the role's additional ConfigMaps.
+func (r *RoleConf) ConfigMaps() []string {
+	return annotationValues(r.conf[r.annotationsKey], r.configMapsAnnotation)
+}
+
+func (r *RoleConf) prefixed(prefix string) map[string]string {
+	result := make(map[string]string)
+	for k, v := range r.conf {
+		if strings.HasPrefix(k, prefix) {
+			result[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return result
+}
+
+// setAnnotationValue sets annotationKey=value within the comma-joined list of "key=value" pairs
+// stored at conf[confKey], replacing any pair already present for annotationKey.
+func setAnnotationValue(conf map[string]string, confKey string, annotationKey string, value string) {
+	var entries []string
+	if existing := conf[confKey]; existing != "" {
+		entries = strings.Split(existing, ",")
+	}
+	replaced := false
+	for i, entry := range entries {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) == 2 && kv[0] == annotationKey {
+			entries[i] = annotationKey + "=" + value
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		entries = append(entries, annotationKey+"="+value)
+	}
+	conf[confKey] = strings.Join(entries, ",")
+}
+
+// appendAnnotationValue appends value to the ";"-separated list already set for annotationKey,
+// replacing it in place via setAnnotationValue rather than creating a duplicate "key=value" pair.
+func appendAnnotationValue(conf map[string]string, confKey string, annotationKey string, value string) {
+	values := append(annotationValues(conf[confKey], annotationKey), value)
+	setAnnotationValue(conf, confKey, annotationKey, strings.Join(values, ";"))
+}
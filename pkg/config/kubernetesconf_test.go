@@ -0,0 +1,87 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+)
+
+func TestNewKubernetesConf_DriverAndExecutorConfAreScoped(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	conf := NewKubernetesConf(app)
+
+	if err := conf.DriverConf().SetLabel("team", "data-eng"); err != nil {
+		t.Fatalf("DriverConf().SetLabel returned unexpected error: %v", err)
+	}
+	conf.ExecutorConf().SetLabel("team", "data-eng")
+
+	if got := conf.DriverConf().Labels(); got["team"] != "data-eng" {
+		t.Errorf("DriverConf().Labels()[team] = %q, want %q", got["team"], "data-eng")
+	}
+	if _, ok := conf.Snapshot()[executorLabelPrefix+"team"]; !ok {
+		t.Errorf("Snapshot() missing %s, executor label was not namespaced separately from the driver's", executorLabelPrefix+"team")
+	}
+}
+
+func TestRoleConf_SetLabel_RejectsReservedLabels(t *testing.T) {
+	r := &RoleConf{conf: make(map[string]string), labelPrefix: driverLabelPrefix}
+
+	if err := r.SetLabel(SparkAppIDLabel, "some-app-id"); err == nil {
+		t.Fatalf("SetLabel(%s, ...) should be rejected, got nil error", SparkAppIDLabel)
+	}
+	if err := r.SetLabel("team", "data-eng"); err != nil {
+		t.Fatalf("SetLabel on a non-reserved key returned unexpected error: %v", err)
+	}
+	if got := r.Labels(); got["team"] != "data-eng" {
+		t.Errorf("Labels()[team] = %q, want %q", got["team"], "data-eng")
+	}
+}
+
+func TestRoleConf_NodeSelectors(t *testing.T) {
+	r := &RoleConf{conf: make(map[string]string), nodeSelectorPrefix: driverNodeSelectorPrefix}
+	r.SetNodeSelector("disktype", "ssd")
+
+	want := map[string]string{"disktype": "ssd"}
+	if got := r.NodeSelectors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeSelectors() = %v, want %v", got, want)
+	}
+}
+
+func TestRoleConf_SetAnnotation_ReplacesRatherThanDuplicates(t *testing.T) {
+	r := &RoleConf{conf: make(map[string]string), annotationsKey: SparkDriverAnnotationsKey}
+
+	r.SetAnnotation("prometheus.io/scrape", "true")
+	r.SetAnnotation("prometheus.io/scrape", "false")
+
+	got := r.Annotations()
+	if len(got) != 1 || got["prometheus.io/scrape"] != "false" {
+		t.Errorf("Annotations() = %v, want exactly one entry with the latest value", got)
+	}
+}
+
+func TestRoleConf_EnvVars(t *testing.T) {
+	r := &RoleConf{conf: make(map[string]string), envVarPrefix: DriverEnvVarConfigKeyPrefix}
+	r.SetEnvVar("JAVA_HOME", "/usr/lib/jvm/java-11")
+
+	want := map[string]string{"JAVA_HOME": "/usr/lib/jvm/java-11"}
+	if got := r.EnvVars(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvVars() = %v, want %v", got, want)
+	}
+}
+
+func TestRoleConf_AddSecret_AccumulatesMultipleEntries(t *testing.T) {
+	r := &RoleConf{
+		conf:              make(map[string]string),
+		annotationsKey:    SparkDriverAnnotationsKey,
+		secretsAnnotation: DriverSecretsAnnotation,
+	}
+
+	r.AddSecret("db-creds", "/mnt/secrets/db")
+	r.AddSecret("gcp-sa", "/mnt/secrets/gcp")
+
+	want := []string{"db-creds:/mnt/secrets/db", "gcp-sa:/mnt/secrets/gcp"}
+	if got := r.Secrets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Secrets() = %v, want %v", got, want)
+	}
+}
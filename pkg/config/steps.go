@@ -0,0 +1,399 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// appSecretVolumeName derives a Pod volume name that is unique per role and per occurrence of
+// secretName in the role's secrets annotation, so mounting the same Secret at two different paths
+// does not produce two volumes with an identical, Pod-rejecting name.
+func appSecretVolumeName(role SparkRole, index int, secretName string) string {
+	return fmt.Sprintf("%s-secret-%d-%s", role, index, secretName)
+}
+
+// applySecretTypeEnv sets the environment variable a typed Secret (GCP service account, Hadoop
+// delegation token) needs beyond its VolumeMount, preserving the "type" field of the
+// DriverSecretsAnnotation/ExecutorSecretsAnnotation "name:path:type" format.
+func applySecretTypeEnv(secretType string, secretName string, mountPath string, container *apiv1.Container) {
+	switch secretType {
+	case SecretTypeGCPServiceAccount:
+		container.Env = append(container.Env, apiv1.EnvVar{
+			Name:  GoogleApplicationCredentialsEnvVar,
+			Value: filepath.Join(mountPath, secretName+".json"),
+		})
+	case SecretTypeHadoopDelegationToken:
+		// HADOOP_TOKEN_FILE_LOCATION must point at the token file itself, not the Secret's mount
+		// directory: UserGroupInformation reads it as a single credentials file, same as the
+		// existing-secret case ConfigureKerberosForDriver handles.
+		container.Env = append(container.Env, apiv1.EnvVar{
+			Name:  HadoopTokenFileLocationEnvVar,
+			Value: filepath.Join(mountPath, DefaultKerberosTokenSecretKey),
+		})
+	}
+}
+
+// annotationValues extracts the ";"-separated value of annotationKey out of annotations, which is
+// itself the comma-joined "key=value" serialization AddConfigMapAnnotation produces for
+// spark.kubernetes.{driver,executor}.annotations. It returns nil if annotationKey is not present.
+func annotationValues(annotations string, annotationKey string) []string {
+	for _, pair := range strings.Split(annotations, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 && kv[0] == annotationKey {
+			return strings.Split(kv[1], ";")
+		}
+	}
+	return nil
+}
+
+// SparkRole identifies whether a FeatureStep is configuring a driver or an executor Pod.
+type SparkRole string
+
+const (
+	// SparkRoleDriver identifies the driver Pod.
+	SparkRoleDriver SparkRole = "driver"
+	// SparkRoleExecutor identifies an executor Pod.
+	SparkRoleExecutor SparkRole = "executor"
+
+	// SparkDriverContainerName is the name of the main Spark container in the driver Pod.
+	SparkDriverContainerName = "spark-kubernetes-driver"
+	// SparkExecutorContainerName is the name of the main Spark container in executor Pods.
+	SparkExecutorContainerName = "executor"
+
+	// LocalDirsVolumeNamePrefix prefixes the emptyDir volumes LocalDirsStep adds for shuffle spill.
+	LocalDirsVolumeNamePrefix = "spark-local-dir-"
+	// DefaultLocalDir is the single scratch directory LocalDirsStep mounts when the application does
+	// not declare its own via spark.local.dir.
+	DefaultLocalDir = "/var/data/spark-local-dir"
+	// SparkLocalDirsEnvVar is the environment variable Spark reads its comma-separated scratch
+	// directories from.
+	SparkLocalDirsEnvVar = "SPARK_LOCAL_DIRS"
+)
+
+// LegacyAnnotationStep mounts the additional ConfigMaps declared by
+// DriverConfigMapsAnnotation/ExecutorConfigMapsAnnotation, each entry formatted "name:mountPath". It
+// is the one piece of the pre-FeatureStep annotation-driven mutation path without a typed step of its
+// own yet (SparkConfigMapAnnotation/HadoopConfigMapAnnotation are covered by SparkConfigMapStep/
+// HadoopConfigMapStep, and DriverSecretsAnnotation/ExecutorSecretsAnnotation by MountSecretsStep), so
+// SparkApplications that still set it directly, rather than going through a Builder, keep working
+// during the migration to the pipeline.
+type LegacyAnnotationStep struct {
+	app  *v1alpha1.SparkApplication
+	role SparkRole
+}
+
+// NewLegacyAnnotationStep returns a LegacyAnnotationStep for app and role.
+func NewLegacyAnnotationStep(app *v1alpha1.SparkApplication, role SparkRole) *LegacyAnnotationStep {
+	return &LegacyAnnotationStep{app: app, role: role}
+}
+
+func (s *LegacyAnnotationStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	annotationsKey := SparkDriverAnnotationsKey
+	configMapsAnnotation := DriverConfigMapsAnnotation
+	if s.role == SparkRoleExecutor {
+		annotationsKey = SparkExecutorAnnotationsKey
+		configMapsAnnotation = ExecutorConfigMapsAnnotation
+	}
+	for i, entry := range annotationValues(s.app.Spec.SparkConf[annotationsKey], configMapsAnnotation) {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		configMapName, mountPath := parts[0], parts[1]
+		volumeName := fmt.Sprintf("%s-configmap-%d-%s", s.role, i, configMapName)
+		addConfigMapVolumeToPod(configMapName, volumeName, pod.Pod)
+		pod.Container.VolumeMounts = append(pod.Container.VolumeMounts, apiv1.VolumeMount{
+			Name:      volumeName,
+			ReadOnly:  true,
+			MountPath: mountPath,
+		})
+	}
+	return pod, nil
+}
+
+func (s *LegacyAnnotationStep) AdditionalResources() []runtime.Object { return nil }
+func (s *LegacyAnnotationStep) SparkConfOverrides() map[string]string { return nil }
+
+// BasicStep sets the labels every driver/executor Pod gets regardless of other configuration.
+type BasicStep struct {
+	app  *v1alpha1.SparkApplication
+	role SparkRole
+}
+
+// NewBasicStep returns a BasicStep for app and role.
+func NewBasicStep(app *v1alpha1.SparkApplication, role SparkRole) *BasicStep {
+	return &BasicStep{app: app, role: role}
+}
+
+func (s *BasicStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	if pod.Pod.Labels == nil {
+		pod.Pod.Labels = make(map[string]string)
+	}
+	pod.Pod.Labels[SparkAppIDLabel] = string(s.app.UID)
+	return pod, nil
+}
+
+func (s *BasicStep) AdditionalResources() []runtime.Object { return nil }
+func (s *BasicStep) SparkConfOverrides() map[string]string { return nil }
+
+// SparkConfigMapStep mounts the Spark ConfigMap created by CreateSparkConfigMap, if any.
+type SparkConfigMapStep struct {
+	app *v1alpha1.SparkApplication
+}
+
+// NewSparkConfigMapStep returns a SparkConfigMapStep for app. The step behaves the same for both
+// roles, since the Spark ConfigMap is mounted into both the driver and every executor.
+func NewSparkConfigMapStep(app *v1alpha1.SparkApplication) *SparkConfigMapStep {
+	return &SparkConfigMapStep{app: app}
+}
+
+func (s *SparkConfigMapStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	if s.app.Spec.SparkConfigMap == nil {
+		return pod, nil
+	}
+	volumeName := AddSparkConfigMapVolumeToPod(*s.app.Spec.SparkConfigMap, pod.Pod)
+	MountSparkConfigMapToContainer(volumeName, DefaultSparkConfDir, pod.Container)
+	return pod, nil
+}
+
+func (s *SparkConfigMapStep) AdditionalResources() []runtime.Object { return nil }
+func (s *SparkConfigMapStep) SparkConfOverrides() map[string]string { return nil }
+
+// HadoopConfigMapStep mounts the Hadoop ConfigMap created by CreateHadoopConfigMap, if any. It is
+// only ever added to the driver builder: executors never receive raw Hadoop configuration directly,
+// the driver serializes the resolved configuration with the tasks it schedules.
+type HadoopConfigMapStep struct {
+	app *v1alpha1.SparkApplication
+}
+
+// NewHadoopConfigMapStep returns a HadoopConfigMapStep for app.
+func NewHadoopConfigMapStep(app *v1alpha1.SparkApplication) *HadoopConfigMapStep {
+	return &HadoopConfigMapStep{app: app}
+}
+
+func (s *HadoopConfigMapStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	if s.app.Spec.HadoopConfigMap == nil {
+		return pod, nil
+	}
+	volumeName := AddHadoopConfigMapVolumeToPod(*s.app.Spec.HadoopConfigMap, pod.Pod)
+	MountHadoopConfigMapToContainer(volumeName, DefaultHadoopConfDir, pod.Container)
+	return pod, nil
+}
+
+func (s *HadoopConfigMapStep) AdditionalResources() []runtime.Object { return nil }
+func (s *HadoopConfigMapStep) SparkConfOverrides() map[string]string { return nil }
+
+const (
+	// SecretTypeGeneric secrets are mounted with no special environment wiring beyond the VolumeMount.
+	SecretTypeGeneric = "Generic"
+	// SecretTypeGCPServiceAccount secrets additionally get GOOGLE_APPLICATION_CREDENTIALS pointed at
+	// the mounted service account key file, named "<secretName>.json" by convention.
+	SecretTypeGCPServiceAccount = "GCPServiceAccount"
+	// SecretTypeHadoopDelegationToken secrets additionally get HADOOP_TOKEN_FILE_LOCATION pointed at
+	// the DefaultKerberosTokenSecretKey file under the mount path, the same env var
+	// ConfigureKerberosForDriver sets for the existing-secret case.
+	SecretTypeHadoopDelegationToken = "HadoopDelegationToken"
+
+	// GoogleApplicationCredentialsEnvVar is the environment variable the Google Cloud client libraries
+	// read the service account key path from.
+	GoogleApplicationCredentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+// MountSecretsStep mounts the Secrets declared by DriverSecretsAnnotation/ExecutorSecretsAnnotation,
+// each entry formatted "name:mountPath:type", or "name:mountPath" (as RoleConf.AddSecret writes it) for
+// a SecretTypeGeneric Secret. It keeps reading the existing annotation format rather
+// than a typed field so that SparkApplications already relying on it keep working unchanged while
+// they migrate to the pipeline; KubernetesConf will replace this with a typed view of driver/executor
+// secrets.
+type MountSecretsStep struct {
+	app  *v1alpha1.SparkApplication
+	role SparkRole
+}
+
+// NewMountSecretsStep returns a MountSecretsStep for app and role.
+func NewMountSecretsStep(app *v1alpha1.SparkApplication, role SparkRole) *MountSecretsStep {
+	return &MountSecretsStep{app: app, role: role}
+}
+
+func (s *MountSecretsStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	annotationsKey := SparkDriverAnnotationsKey
+	secretsAnnotation := DriverSecretsAnnotation
+	if s.role == SparkRoleExecutor {
+		annotationsKey = SparkExecutorAnnotationsKey
+		secretsAnnotation = ExecutorSecretsAnnotation
+	}
+	for i, entry := range annotationValues(s.app.Spec.SparkConf[annotationsKey], secretsAnnotation) {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		secretName, mountPath, secretType := parts[0], parts[1], SecretTypeGeneric
+		if len(parts) == 3 {
+			secretType = parts[2]
+		}
+		// Index the volume name by occurrence, not just secretName: the same Secret mounted at two
+		// different paths must still produce two distinct Pod volumes.
+		mountSecretVolume(secretName, appSecretVolumeName(s.role, i, secretName), mountPath, true, pod.Pod, pod.Container)
+		applySecretTypeEnv(secretType, secretName, mountPath, pod.Container)
+	}
+	return pod, nil
+}
+
+func (s *MountSecretsStep) AdditionalResources() []runtime.Object { return nil }
+func (s *MountSecretsStep) SparkConfOverrides() map[string]string { return nil }
+
+// MountVolumesStep mounts app.Spec.Volumes, and the role-specific subset of VolumeMounts that
+// reference them, into the Pod.
+type MountVolumesStep struct {
+	app  *v1alpha1.SparkApplication
+	role SparkRole
+}
+
+// NewMountVolumesStep returns a MountVolumesStep for app and role.
+func NewMountVolumesStep(app *v1alpha1.SparkApplication, role SparkRole) *MountVolumesStep {
+	return &MountVolumesStep{app: app, role: role}
+}
+
+func (s *MountVolumesStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	mounts := s.app.Spec.Executor.VolumeMounts
+	if s.role == SparkRoleDriver {
+		mounts = s.app.Spec.Driver.VolumeMounts
+	}
+	if len(mounts) == 0 {
+		return pod, nil
+	}
+
+	volumesByName := make(map[string]apiv1.Volume, len(s.app.Spec.Volumes))
+	for _, volume := range s.app.Spec.Volumes {
+		volumesByName[volume.Name] = volume
+	}
+	for _, mount := range mounts {
+		if volume, ok := volumesByName[mount.Name]; ok {
+			pod.Pod.Spec.Volumes = append(pod.Pod.Spec.Volumes, volume)
+		}
+		pod.Container.VolumeMounts = append(pod.Container.VolumeMounts, mount)
+	}
+	return pod, nil
+}
+
+func (s *MountVolumesStep) AdditionalResources() []runtime.Object { return nil }
+func (s *MountVolumesStep) SparkConfOverrides() map[string]string { return nil }
+
+// KerberosStep applies ConfigureKerberosForDriver. It is only ever added to the driver builder.
+type KerberosStep struct {
+	app       *v1alpha1.SparkApplication
+	sparkConf map[string]string
+}
+
+// NewKerberosStep returns a KerberosStep for app.
+func NewKerberosStep(app *v1alpha1.SparkApplication) *KerberosStep {
+	return &KerberosStep{app: app}
+}
+
+func (s *KerberosStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	// A misconfigured Kerberos spec (e.g. a keytab with no principal) must reject the
+	// SparkApplication rather than silently launch the driver with no Kerberos at all, so the error
+	// is returned instead of discarded.
+	sparkConf, err := ConfigureKerberosForDriver(s.app, pod.Pod, pod.Container)
+	if err != nil {
+		return pod, err
+	}
+	s.sparkConf = sparkConf
+	return pod, nil
+}
+
+func (s *KerberosStep) AdditionalResources() []runtime.Object { return nil }
+
+// SparkConfOverrides returns spark.kerberos.principal/spark.kerberos.keytab computed by ConfigurePod,
+// or nil before ConfigurePod has run or if no Kerberos configuration was declared.
+func (s *KerberosStep) SparkConfOverrides() map[string]string { return s.sparkConf }
+
+// SparkLocalDirKey is the Spark configuration key for the user's own comma-separated scratch
+// directories. LocalDirsStep honors it instead of always mounting DefaultLocalDir, since a user who
+// set it is relying on Spark writing shuffle spill there, e.g. onto a pre-provisioned volume.
+const SparkLocalDirKey = "spark.local.dir"
+
+// LocalDirsStep mounts an emptyDir scratch volume per directory listed in spark.local.dir (or a
+// single DefaultLocalDir if the application didn't set it) and points SPARK_LOCAL_DIRS at them, so
+// shuffle spill isn't written to the container's writable layer.
+type LocalDirsStep struct {
+	app *v1alpha1.SparkApplication
+}
+
+// NewLocalDirsStep returns a LocalDirsStep for app.
+func NewLocalDirsStep(app *v1alpha1.SparkApplication) *LocalDirsStep {
+	return &LocalDirsStep{app: app}
+}
+
+func (s *LocalDirsStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	dirs := []string{DefaultLocalDir}
+	if localDir := s.app.Spec.SparkConf[SparkLocalDirKey]; localDir != "" {
+		dirs = strings.Split(localDir, ",")
+	}
+
+	for i, dir := range dirs {
+		volumeName := fmt.Sprintf("%s%d", LocalDirsVolumeNamePrefix, i)
+		pod.Pod.Spec.Volumes = append(pod.Pod.Spec.Volumes, apiv1.Volume{
+			Name:         volumeName,
+			VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}},
+		})
+		pod.Container.VolumeMounts = append(pod.Container.VolumeMounts, apiv1.VolumeMount{
+			Name:      volumeName,
+			MountPath: dir,
+		})
+	}
+	pod.Container.Env = append(pod.Container.Env, apiv1.EnvVar{
+		Name:  SparkLocalDirsEnvVar,
+		Value: strings.Join(dirs, ","),
+	})
+	return pod, nil
+}
+
+func (s *LocalDirsStep) AdditionalResources() []runtime.Object { return nil }
+func (s *LocalDirsStep) SparkConfOverrides() map[string]string { return nil }
+
+// DependencyResolutionStep applies RemoteDependencyManager.ConfigureRemoteDependencies, adding the
+// download init-container and rewriting spark.jars/spark.files to local paths.
+type DependencyResolutionStep struct {
+	app        *v1alpha1.SparkApplication
+	namespace  string
+	depManager *RemoteDependencyManager
+	configMap  *apiv1.ConfigMap
+	sparkConf  map[string]string
+}
+
+// NewDependencyResolutionStep returns a DependencyResolutionStep for app, building the dependency
+// ConfigMap up front so AdditionalResources can return it without redoing the work ConfigurePod does.
+func NewDependencyResolutionStep(app *v1alpha1.SparkApplication, namespace string, depManager *RemoteDependencyManager) *DependencyResolutionStep {
+	return &DependencyResolutionStep{
+		app:        app,
+		namespace:  namespace,
+		depManager: depManager,
+		configMap:  depManager.BuildDependenciesConfigMap(app, namespace),
+	}
+}
+
+func (s *DependencyResolutionStep) ConfigurePod(pod SparkPod) (SparkPod, error) {
+	if s.configMap == nil {
+		return pod, nil
+	}
+	s.sparkConf = s.depManager.ConfigureRemoteDependencies(s.app, s.configMap.Name, pod.Pod, pod.Container)
+	return pod, nil
+}
+
+func (s *DependencyResolutionStep) AdditionalResources() []runtime.Object {
+	if s.configMap == nil {
+		return nil
+	}
+	return []runtime.Object{s.configMap}
+}
+
+// SparkConfOverrides returns spark.jars/spark.files/spark.submit.pyFiles rewritten to local download
+// paths by ConfigurePod, or nil before ConfigurePod has run or if no dependencies were declared.
+func (s *DependencyResolutionStep) SparkConfOverrides() map[string]string { return s.sparkConf }
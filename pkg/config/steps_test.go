@@ -0,0 +1,138 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/liyinan926/spark-operator/pkg/apis/v1alpha1"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func newTestPod() SparkPod {
+	return SparkPod{
+		Pod:       &apiv1.Pod{},
+		Container: &apiv1.Container{Name: SparkDriverContainerName},
+	}
+}
+
+func TestImagePullSecretsStep_ConfigurePod(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	app.Spec.ImagePullSecrets = []string{"registry-a", "registry-b"}
+
+	step := NewImagePullSecretsStep(app)
+	pod, err := step.ConfigurePod(newTestPod())
+	if err != nil {
+		t.Fatalf("ConfigurePod returned unexpected error: %v", err)
+	}
+
+	got := pod.Pod.Spec.ImagePullSecrets
+	if len(got) != 2 || got[0].Name != "registry-a" || got[1].Name != "registry-b" {
+		t.Fatalf("ImagePullSecrets = %v, want [registry-a registry-b]", got)
+	}
+	if overrides := step.SparkConfOverrides(); overrides != nil {
+		t.Fatalf("SparkConfOverrides() = %v, want nil", overrides)
+	}
+}
+
+func TestKerberosStep_ConfigurePod_MissingPrincipalRejected(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	app.Spec.HadoopConf = &v1alpha1.HadoopConf{
+		Kerberos: &v1alpha1.KerberosConf{KeytabSecret: "my-keytab-secret"},
+	}
+
+	step := NewKerberosStep(app)
+	if _, err := step.ConfigurePod(newTestPod()); err == nil {
+		t.Fatal("ConfigurePod with a keytab and no principal should return an error, got nil")
+	}
+	if overrides := step.SparkConfOverrides(); overrides != nil {
+		t.Fatalf("SparkConfOverrides() after a failed ConfigurePod = %v, want nil", overrides)
+	}
+}
+
+func TestKerberosStep_ConfigurePod_KeytabSetsSparkConfOverrides(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	app.Spec.HadoopConf = &v1alpha1.HadoopConf{
+		Kerberos: &v1alpha1.KerberosConf{
+			KeytabSecret: "my-keytab-secret",
+			Principal:    "spark@EXAMPLE.COM",
+		},
+	}
+
+	step := NewKerberosStep(app)
+	if _, err := step.ConfigurePod(newTestPod()); err != nil {
+		t.Fatalf("ConfigurePod returned unexpected error: %v", err)
+	}
+
+	overrides := step.SparkConfOverrides()
+	if overrides[SparkKerberosPrincipalKey] != "spark@EXAMPLE.COM" {
+		t.Errorf("SparkConfOverrides()[%s] = %q, want %q", SparkKerberosPrincipalKey, overrides[SparkKerberosPrincipalKey], "spark@EXAMPLE.COM")
+	}
+	if overrides[SparkKerberosKeytabKey] == "" {
+		t.Errorf("SparkConfOverrides()[%s] is empty, want the mounted keytab path", SparkKerberosKeytabKey)
+	}
+	if app.Spec.SparkConf[SparkKerberosPrincipalKey] != "" {
+		t.Errorf("ConfigurePod must not write %s into app.Spec.SparkConf directly, got %q", SparkKerberosPrincipalKey, app.Spec.SparkConf[SparkKerberosPrincipalKey])
+	}
+}
+
+func TestDependencyResolutionStep_NoDepsIsNoOp(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	step := NewDependencyResolutionStep(app, "default", NewRemoteDependencyManager(""))
+
+	pod, err := step.ConfigurePod(newTestPod())
+	if err != nil {
+		t.Fatalf("ConfigurePod returned unexpected error: %v", err)
+	}
+	if len(pod.Pod.Spec.InitContainers) != 0 {
+		t.Fatalf("InitContainers = %v, want none when spec.Deps is unset", pod.Pod.Spec.InitContainers)
+	}
+	if resources := step.AdditionalResources(); resources != nil {
+		t.Fatalf("AdditionalResources() = %v, want nil", resources)
+	}
+	if overrides := step.SparkConfOverrides(); overrides != nil {
+		t.Fatalf("SparkConfOverrides() = %v, want nil", overrides)
+	}
+}
+
+func TestDependencyResolutionStep_JarsSetSparkConfOverrides(t *testing.T) {
+	app := &v1alpha1.SparkApplication{}
+	app.Spec.SparkConf = map[string]string{"spark.jars": "local:///opt/spark/existing.jar"}
+	app.Spec.Deps = &v1alpha1.Dependencies{Jars: []string{"https://example.com/some.jar"}}
+
+	step := NewDependencyResolutionStep(app, "default", NewRemoteDependencyManager(""))
+	pod, err := step.ConfigurePod(newTestPod())
+	if err != nil {
+		t.Fatalf("ConfigurePod returned unexpected error: %v", err)
+	}
+	if len(pod.Pod.Spec.InitContainers) != 1 {
+		t.Fatalf("InitContainers = %v, want exactly one init-container", pod.Pod.Spec.InitContainers)
+	}
+
+	overrides := step.SparkConfOverrides()
+	got := overrides["spark.jars"]
+	if got == "" {
+		t.Fatal("SparkConfOverrides()[spark.jars] is empty")
+	}
+	if got == "local:///opt/spark/existing.jar" {
+		t.Fatalf("SparkConfOverrides()[spark.jars] = %q, want it to also include the downloaded jar", got)
+	}
+}
+
+func TestMergeCommaList(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string
+		newValues []string
+		want      string
+	}{
+		{name: "empty existing", existing: "", newValues: []string{"a"}, want: "a"},
+		{name: "appends without dropping existing", existing: "a,b", newValues: []string{"c"}, want: "a,b,c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCommaList(tt.existing, tt.newValues); got != tt.want {
+				t.Errorf("mergeCommaList(%q, %v) = %q, want %q", tt.existing, tt.newValues, got, tt.want)
+			}
+		})
+	}
+}